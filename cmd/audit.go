@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Produce reports for compliance and review purposes",
+}
+
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the set of inactive-user candidates for the selected teams",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connection()
+		if err != nil {
+			return err
+		}
+		if err := validateScope(); err != nil {
+			return err
+		}
+
+		candidates, err := findInactiveUsers(conn)
+		if err != nil {
+			return err
+		}
+
+		return writeReport(exportFormat, exportFile, candidates)
+	},
+}
+
+func init() {
+	auditExportCmd.Flags().StringVar(&userTeam, "team", "", "The name of the Mattermost team.  One of --team, --teams, or --all-teams is required.")
+	auditExportCmd.Flags().StringVar(&userTeams, "teams", "", "Comma-separated list of Mattermost team names to scan.")
+	auditExportCmd.Flags().BoolVar(&userAllTeams, "all-teams", false, "Scan every team on the server, deduplicating users that belong to more than one.")
+	ageDescription := fmt.Sprintf("How long a user must have been inactive to be considered. Accepts a plain number of days or a duration string (e.g. 180d, 4320h). [Default: %s]", defaultInactiveSince)
+	auditExportCmd.Flags().StringVar(&userInactiveSince, "inactive-since", defaultInactiveSince, ageDescription)
+	auditExportCmd.Flags().IntVar(&fetchWorkers, "fetch-workers", defaultFetchWorkers, "Number of concurrent workers used to page through the users API.")
+	auditExportCmd.Flags().Float64Var(&rateLimit, "rate-limit", 0, "Maximum Mattermost API requests per second (0 = unlimited).")
+	auditExportCmd.Flags().StringVar(&exportFormat, "output", "csv", "Render identified users as csv, json, or table.")
+	auditExportCmd.Flags().StringVar(&exportFile, "output-file", "", "Write the report to this path instead of stdout.")
+	auditExportCmd.Flags().BoolVar(&includeSSO, "include-sso", false, "Include LDAP/SAML-managed users, which are otherwise skipped.")
+	auditExportCmd.Flags().BoolVar(&includeBots, "include-bots", false, "Include bot accounts, which are otherwise skipped.")
+	auditExportCmd.Flags().StringVar(&skipRoles, "skip-roles", "system_admin", "Comma-separated list of roles to exclude from consideration.")
+	auditExportCmd.Flags().StringVar(&requireRole, "require-role", "", "Limit consideration to users holding this role.")
+	auditExportCmd.Flags().StringVar(&skipUsers, "skip-users", "", "Comma-separated list of UserIDs to exclude.")
+	auditExportCmd.Flags().StringVar(&skipUsernamesFile, "skip-usernames-file", "", "Path to a file of usernames (one per line) to exclude.")
+
+	auditCmd.AddCommand(auditExportCmd)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,164 @@
+// Package cmd implements the mm-inactive-users command-line interface using
+// Cobra. Connection details are shared across every subcommand via persistent
+// flags on the root command; each subcommand is responsible only for its own
+// behavior (listing, deactivating, reactivating, notifying users, and so on).
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jlandells/mm-inactive-users/internal/config"
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+	"github.com/jlandells/mm-inactive-users/internal/mattermost"
+	"github.com/spf13/cobra"
+)
+
+// Version is set by main, from its own build-time Version, before Execute runs.
+var Version = "development"
+
+const (
+	defaultPort   = "8065"
+	defaultScheme = "http"
+)
+
+var (
+	configFile string
+
+	mmURL    string
+	mmPort   string
+	mmScheme string
+	mmToken  string
+
+	debugFlag bool
+)
+
+// rootCmd is the base command, run when mm-inactive-users is invoked with no subcommand.
+var rootCmd = &cobra.Command{
+	Use:   "mm-inactive-users",
+	Short: "Identify and manage inactive Mattermost users",
+	Long: "mm-inactive-users connects to a Mattermost server and helps administrators\n" +
+		"find, report on, and deactivate users that have been inactive for a\n" +
+		"configurable number of days.",
+	SilenceUsage:      true,
+	PersistentPreRunE: loadConnectionDefaults,
+}
+
+// Execute runs the root command, exiting the process on error.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Path to a YAML/TOML file containing default values for the flags below")
+
+	rootCmd.PersistentFlags().StringVar(&mmURL, "url", "", "The URL of the Mattermost instance (without the HTTP scheme)")
+	rootCmd.PersistentFlags().StringVar(&mmPort, "port", "", "The TCP port used by Mattermost. [Default: "+defaultPort+"]")
+	rootCmd.PersistentFlags().StringVar(&mmScheme, "scheme", "", "The HTTP scheme to be used (http/https). [Default: "+defaultScheme+"]")
+	rootCmd.PersistentFlags().StringVar(&mmToken, "token", "", "The auth token used to connect to Mattermost")
+	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false, "Enable debug output")
+
+	rootCmd.AddCommand(versionCmd)
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show version information and exit",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Printf("\nmm-inactive-users - Version: %s\n\n", Version)
+		return nil
+	},
+}
+
+// getEnvWithDefault retrieves an environment variable, falling back to defaultValue if unset.
+func getEnvWithDefault(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
+
+// loadConnectionDefaults resolves connection settings, plus --team and
+// --inactive-since, in priority order: explicit flag, config file,
+// environment variable, built-in default. It runs before every subcommand
+// via PersistentPreRunE.
+func loadConnectionDefaults(cmd *cobra.Command, args []string) error {
+	defaults, err := config.Load(configFile)
+	if err != nil {
+		return err
+	}
+
+	if mmURL == "" {
+		mmURL = firstNonEmpty(defaults.URL, getEnvWithDefault("MM_URL", ""))
+	}
+	if mmPort == "" {
+		mmPort = firstNonEmpty(defaults.Port, getEnvWithDefault("MM_PORT", defaultPort))
+	}
+	if mmScheme == "" {
+		mmScheme = firstNonEmpty(defaults.Scheme, getEnvWithDefault("MM_SCHEME", defaultScheme))
+	}
+	if mmToken == "" {
+		mmToken = firstNonEmpty(defaults.Token, getEnvWithDefault("MM_TOKEN", ""))
+	}
+	if !debugFlag {
+		debugFlag = getEnvWithDefault("MM_DEBUG", "") != ""
+	}
+	logging.SetDebug(debugFlag)
+
+	if !cmd.Flags().Changed("team") && userTeam == "" && !userAllTeams && userTeams == "" {
+		userTeam = firstNonEmpty(defaults.Team, getEnvWithDefault("MM_TEAM", ""))
+	}
+	if !cmd.Flags().Changed("inactive-since") && defaults.Age > 0 {
+		userInactiveSince = strconv.Itoa(defaults.Age)
+	} else if !cmd.Flags().Changed("inactive-since") {
+		if envAge := getEnvWithDefault("MM_AGE", ""); envAge != "" {
+			userInactiveSince = envAge
+		}
+	}
+	if cmd.Flags().Lookup("inactive-since") != nil {
+		age, err := parseInactiveSince(userInactiveSince)
+		if err != nil {
+			return err
+		}
+		userAge = age
+	}
+
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// connection builds a mattermost.Connection from the resolved persistent flags,
+// failing with a clear error message if anything required is still missing.
+func connection() (mattermost.Connection, error) {
+	var missing []string
+	if mmURL == "" {
+		missing = append(missing, "--url (or MM_URL)")
+	}
+	if mmScheme == "" {
+		missing = append(missing, "--scheme (or MM_SCHEME)")
+	}
+	if mmToken == "" {
+		missing = append(missing, "--token (or MM_TOKEN)")
+	}
+	if len(missing) > 0 {
+		return mattermost.Connection{}, fmt.Errorf("missing required connection settings: %v", missing)
+	}
+
+	return mattermost.Connection{
+		URL:    mmURL,
+		Port:   mmPort,
+		Scheme: mmScheme,
+		Token:  mmToken,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jlandells/mm-inactive-users/internal/mattermost"
+	"github.com/spf13/cobra"
+)
+
+var teamCmd = &cobra.Command{
+	Use:   "team",
+	Short: "Inspect Mattermost teams",
+}
+
+var teamListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every team visible to the configured auth token",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connection()
+		if err != nil {
+			return err
+		}
+
+		teams, err := mattermost.ListTeams(conn)
+		if err != nil {
+			return err
+		}
+
+		if len(teams) == 0 {
+			fmt.Println("No teams found.")
+			return nil
+		}
+
+		fmt.Printf("\nTeams available in Mattermost (internal name in brackets):\n\n")
+		for _, team := range teams {
+			fmt.Printf(" - %s (%s)\n", team.DisplayName, team.Name)
+		}
+		fmt.Println()
+
+		return nil
+	},
+}
+
+func init() {
+	teamCmd.AddCommand(teamListCmd)
+	rootCmd.AddCommand(teamCmd)
+}
@@ -0,0 +1,532 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+	"github.com/jlandells/mm-inactive-users/internal/mattermost"
+	"github.com/jlandells/mm-inactive-users/internal/notify"
+	"github.com/jlandells/mm-inactive-users/internal/report"
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultAge           = 180
+	defaultInactiveSince = "180d"
+	defaultFetchWorkers  = 4
+	defaultDeleteWorkers = 2
+	defaultNotifyBefore  = "14d"
+)
+
+var (
+	userTeam     string
+	userTeams    string
+	userAllTeams bool
+
+	userAge           int
+	userInactiveSince string
+	userHardDelete    bool
+	userAssumeYes     bool
+
+	fetchWorkers  int
+	deleteWorkers int
+	rateLimit     float64
+
+	notifyBefore        string
+	notifyStateFile     string
+	deactivateStateFile string
+	notifyTemplate      string
+
+	outputFormat string
+	outputFile   string
+	exportFormat string
+	exportFile   string
+	inputFile    string
+
+	includeSSO        bool
+	includeBots       bool
+	skipRoles         = "system_admin"
+	requireRole       string
+	skipUsers         string
+	skipUsernamesFile string
+)
+
+// validateScope checks that a team scope was supplied and that --all-teams
+// is not combined with --team or --teams. --team and --teams may be given
+// together; their teams are then scanned together.
+func validateScope() error {
+	if userAllTeams {
+		if userTeam != "" || userTeams != "" {
+			return fmt.Errorf("--all-teams cannot be combined with --team or --teams")
+		}
+		return nil
+	}
+
+	if userTeam == "" && userTeams == "" {
+		return fmt.Errorf("a Mattermost team scope is required; supply --team, --teams, or --all-teams")
+	}
+
+	return nil
+}
+
+// resolveTeams turns --team/--teams into the list of teams to scan. It's
+// unused when --all-teams is set, since that path talks to /api/v4/teams
+// directly rather than scanning a known list.
+func resolveTeams(conn mattermost.Connection) ([]mattermost.Team, error) {
+	names := splitCSV(userTeams)
+	if userTeam != "" {
+		names = append([]string{userTeam}, names...)
+	}
+
+	teams := make([]mattermost.Team, 0, len(names))
+	for _, name := range names {
+		id, err := mattermost.GetTeamID(conn, name)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, mattermost.Team{ID: id, Name: name})
+	}
+
+	return teams, nil
+}
+
+// parseInactiveSince parses an --inactive-since value into a whole number of
+// days. It accepts a plain number of days ("180") or, matching --notify-before,
+// a duration string such as "180d" or "4320h".
+func parseInactiveSince(value string) (int, error) {
+	if days, err := strconv.Atoi(value); err == nil {
+		return days, nil
+	}
+
+	duration, err := notify.ParseGracePeriod(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --inactive-since value %q: %w", value, err)
+	}
+	return int(duration.Hours() / 24), nil
+}
+
+// buildFilter assembles a mattermost.Filter from the resolved flags.
+func buildFilter() (mattermost.Filter, error) {
+	filter := mattermost.NewFilter(userAge)
+	filter.IncludeSSO = includeSSO
+	filter.IncludeBots = includeBots
+
+	for _, role := range splitCSV(skipRoles) {
+		filter.SkipRoles[role] = true
+	}
+	filter.RequireRole = requireRole
+
+	for _, id := range splitCSV(skipUsers) {
+		filter.SkipUserIDs[id] = true
+	}
+
+	if skipUsernamesFile != "" {
+		usernames, err := readLines(skipUsernamesFile)
+		if err != nil {
+			return filter, fmt.Errorf("unable to read --skip-usernames-file %q: %w", skipUsernamesFile, err)
+		}
+		for _, username := range usernames {
+			filter.SkipUsernames[username] = true
+		}
+	}
+
+	return filter, nil
+}
+
+// splitCSV splits a comma-separated flag value, discarding empty entries.
+func splitCSV(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// readLines reads a file of one value per line, skipping blank lines.
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// writeReport renders candidates in the given format, to file if one was
+// supplied, or to stdout otherwise.
+func writeReport(formatName, file string, candidates map[string]mattermost.User) error {
+	format, err := report.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+
+	if file != "" {
+		return report.WriteToFile(file, format, candidates)
+	}
+	return report.Write(os.Stdout, format, candidates)
+}
+
+// defaultStateFile returns ~/.mm-inactive-users/state.json, falling back to a
+// relative path if the user's home directory can't be determined.
+func defaultStateFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".mm-inactive-users/state.json"
+	}
+	return filepath.Join(home, ".mm-inactive-users", "state.json")
+}
+
+// rateLimiter builds a shared token-bucket limiter from --rate-limit, or nil
+// if no limit was requested (the default - unbounded).
+func rateLimiter() *rate.Limiter {
+	if rateLimit <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(rateLimit), 1)
+}
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Inspect and manage individual Mattermost users",
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users that have been inactive for at least --inactive-since days",
+	Long: "list replaces the old --dry-run flag: it always just reports on the set of\n" +
+		"candidate users, without prompting for or taking any destructive action.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connection()
+		if err != nil {
+			return err
+		}
+		if err := validateScope(); err != nil {
+			return err
+		}
+
+		candidates, err := findInactiveUsers(conn)
+		if err != nil {
+			return err
+		}
+
+		if len(candidates) == 0 {
+			logging.LogMessage(logging.InfoLevel, "No users found that have been inactive for more than "+strconv.Itoa(userAge)+" days")
+			return nil
+		}
+
+		if outputFormat != "" {
+			return writeReport(outputFormat, outputFile, candidates)
+		}
+
+		mattermost.PrintAllIdentifiedUsers(candidates)
+		return nil
+	},
+}
+
+var userDeactivateCmd = &cobra.Command{
+	Use:   "deactivate",
+	Short: "Deactivate (or hard-delete) users that have been inactive for at least --inactive-since days",
+	Long: "deactivate is the direct replacement for the original tool's default\n" +
+		"behavior: it identifies candidate users then, unless --yes is supplied,\n" +
+		"prompts interactively before taking action.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connection()
+		if err != nil {
+			return err
+		}
+
+		var candidates map[string]mattermost.User
+		if inputFile != "" {
+			candidates, err = loadCandidatesFromFile(conn, inputFile)
+			if err != nil {
+				return err
+			}
+		} else {
+			if err := validateScope(); err != nil {
+				return err
+			}
+			candidates, err = findInactiveUsers(conn)
+			if err != nil {
+				return err
+			}
+		}
+
+		logging.LogMessage(logging.InfoLevel, "All users reviewed")
+
+		if deactivateStateFile != "" {
+			state, err := notify.Load(deactivateStateFile)
+			if err != nil {
+				return fmt.Errorf("unable to load state file %q: %w", deactivateStateFile, err)
+			}
+			before := len(candidates)
+			candidates = notify.ReadyForDeactivation(candidates, state, time.Now())
+			logging.DebugPrint(fmt.Sprintf("Grace-period filter: %d candidates, %d ready for deactivation", before, len(candidates)))
+		}
+
+		if len(candidates) == 0 {
+			logging.LogMessage(logging.InfoLevel, "No users found that have been inactive for more than "+strconv.Itoa(userAge)+" days")
+			return nil
+		}
+
+		if userAssumeYes {
+			logging.LogMessage(logging.InfoLevel, "Deactivating users")
+			return deactivateAndReport(conn, candidates)
+		}
+
+		prompt := fmt.Sprintf("%d users identified as inactive.  Deactivate them? (Y)es/(N)o/(L)ist/(E)xport: ", len(candidates))
+		allowedKeys := []string{"Y", "N", "L", "E"}
+
+		for {
+			keypress, err := mattermost.PromptForKeypress(prompt, allowedKeys)
+			if err != nil {
+				return fmt.Errorf("error processing user input: %w", err)
+			}
+
+			switch keypress {
+			case "Y":
+				logging.LogMessage(logging.InfoLevel, "Deactivating users")
+				return deactivateAndReport(conn, candidates)
+			case "N":
+				logging.LogMessage(logging.InfoLevel, "Aborting")
+				return nil
+			case "L":
+				mattermost.PrintAllIdentifiedUsers(candidates)
+			case "E":
+				if err := writeReport(exportFormat, exportFile, candidates); err != nil {
+					logging.LogMessage(logging.WarningLevel, "Export failed: "+err.Error())
+				}
+			}
+		}
+	},
+}
+
+// loadCandidatesFromFile reads --input-file and resolves each entry into a
+// full User record, bypassing the Mattermost user scan entirely. This is the
+// re-import half of the audit workflow: run `user list --output csv`, have a
+// manager review/edit the CSV, then feed it back in here.
+func loadCandidatesFromFile(conn mattermost.Connection, path string) (map[string]mattermost.User, error) {
+	identifiers, err := report.ReadIdentifiers(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read input file %q: %w", path, err)
+	}
+	if len(identifiers) == 0 {
+		return nil, fmt.Errorf("input file %q contained no usable UserIDs or usernames", path)
+	}
+
+	return mattermost.GetUsersByIdentifiers(conn, identifiers)
+}
+
+// deactivateAndReport runs the deactivation worker pool then prints a summary
+// of how many users succeeded/failed, rather than logging-and-continuing with
+// no overall picture of the run.
+func deactivateAndReport(conn mattermost.Connection, candidates map[string]mattermost.User) error {
+	result := mattermost.DeactivateUsersConcurrent(conn, candidates, userHardDelete, deleteWorkers, rateLimiter())
+
+	fmt.Printf("\nDeactivation complete: %d succeeded, %d failed\n", len(result.Succeeded), len(result.Failed))
+	for username, err := range result.Failed {
+		logging.LogMessage(logging.WarningLevel, "Failed to deactivate "+username+": "+err.Error())
+	}
+
+	if deactivateStateFile != "" {
+		if err := removeFromState(deactivateStateFile, candidates, result); err != nil {
+			logging.LogMessage(logging.WarningLevel, "Unable to update state file after deactivation: "+err.Error())
+		}
+	}
+
+	return nil
+}
+
+// removeFromState drops the state-file entries for users that were
+// successfully deactivated, since they no longer need grace-period tracking.
+func removeFromState(path string, candidates map[string]mattermost.User, report mattermost.DeactivationReport) error {
+	state, err := notify.Load(path)
+	if err != nil {
+		return err
+	}
+
+	succeeded := make(map[string]bool, len(report.Succeeded))
+	for _, username := range report.Succeeded {
+		succeeded[username] = true
+	}
+
+	for id, user := range candidates {
+		if succeeded[user.Username] {
+			delete(state, id)
+		}
+	}
+
+	return notify.Save(path, state)
+}
+
+var userReactivateCmd = &cobra.Command{
+	Use:   "reactivate",
+	Short: "Reactivate a previously deactivated user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connection()
+		if err != nil {
+			return err
+		}
+		return mattermost.ReactivateUser(conn, args[0])
+	},
+}
+
+var userNotifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Send inactive users a warning ahead of deactivation",
+	Long:  "notify identifies candidate users and sends each of them a warning, without deactivating anyone.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connection()
+		if err != nil {
+			return err
+		}
+		if err := validateScope(); err != nil {
+			return err
+		}
+
+		candidates, err := findInactiveUsers(conn)
+		if err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			logging.LogMessage(logging.InfoLevel, "No users found that have been inactive for more than "+strconv.Itoa(userAge)+" days")
+			return nil
+		}
+
+		gracePeriod, err := notify.ParseGracePeriod(notifyBefore)
+		if err != nil {
+			return fmt.Errorf("invalid --notify-before value %q: %w", notifyBefore, err)
+		}
+
+		state, err := notify.Load(notifyStateFile)
+		if err != nil {
+			return fmt.Errorf("unable to load state file %q: %w", notifyStateFile, err)
+		}
+
+		now := time.Now()
+		notified := 0
+		for id, user := range candidates {
+			if entry, alreadyNotified := state[id]; alreadyNotified && now.Before(entry.ScheduledDeactivationAt) {
+				logging.DebugPrint("Already notified " + user.Username + "; grace period has not yet elapsed")
+				continue
+			}
+
+			scheduledAt := now.Add(gracePeriod)
+			message, err := notify.RenderMessage(notifyTemplate, notify.MessageData{
+				Username:         user.Username,
+				DaysInactive:     user.DaysSinceLastActivity,
+				DeactivationDate: scheduledAt.Format("02-01-2006"),
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := mattermost.SendDirectMessage(conn, user.UserID, message); err != nil {
+				logging.LogMessage(logging.WarningLevel, "Failed to notify "+user.Username+": "+err.Error())
+				continue
+			}
+
+			state[id] = notify.Entry{UserID: id, NotifiedAt: now, ScheduledDeactivationAt: scheduledAt}
+			notified++
+		}
+
+		if err := notify.Save(notifyStateFile, state); err != nil {
+			return fmt.Errorf("unable to save state file %q: %w", notifyStateFile, err)
+		}
+
+		logging.LogMessage(logging.InfoLevel, fmt.Sprintf("Notified %d of %d candidate users", notified, len(candidates)))
+		return nil
+	},
+}
+
+// findInactiveUsers resolves --team/--teams/--all-teams into the candidate
+// set for deactivation, using a bounded pool of fetch workers. When
+// --all-teams is set, it prefers a single server-wide users listing, falling
+// back to per-team iteration only if the server rejects that for the
+// token's permissions.
+func findInactiveUsers(conn mattermost.Connection) (map[string]mattermost.User, error) {
+	filter, err := buildFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	if userAllTeams {
+		candidates, err := mattermost.FetchUsersServerWide(conn, filter, fetchWorkers, rateLimiter())
+		if err == nil {
+			return candidates, nil
+		}
+		if !errors.Is(err, mattermost.ErrPermissionDenied) {
+			return nil, err
+		}
+
+		logging.LogMessage(logging.WarningLevel, "Server-wide user listing was denied; falling back to per-team iteration")
+		teams, err := mattermost.ListTeams(conn)
+		if err != nil {
+			return nil, err
+		}
+		return mattermost.FetchUsersAcrossTeams(conn, teams, filter, fetchWorkers, rateLimiter())
+	}
+
+	teams, err := resolveTeams(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(teams) == 1 {
+		return mattermost.FetchUsersConcurrent(conn, teams[0].ID, filter, fetchWorkers, rateLimiter())
+	}
+	return mattermost.FetchUsersAcrossTeams(conn, teams, filter, fetchWorkers, rateLimiter())
+}
+
+func init() {
+	for _, c := range []*cobra.Command{userListCmd, userDeactivateCmd, userNotifyCmd} {
+		c.Flags().StringVar(&userTeam, "team", "", "The name of the Mattermost team.  One of --team, --teams, or --all-teams is required.")
+		c.Flags().StringVar(&userTeams, "teams", "", "Comma-separated list of Mattermost team names to scan.")
+		c.Flags().BoolVar(&userAllTeams, "all-teams", false, "Scan every team on the server, deduplicating users that belong to more than one.")
+		ageDescription := fmt.Sprintf("How long a user must have been inactive to be considered. Accepts a plain number of days or a duration string (e.g. 180d, 4320h). [Default: %s]", defaultInactiveSince)
+		c.Flags().StringVar(&userInactiveSince, "inactive-since", defaultInactiveSince, ageDescription)
+		c.Flags().IntVar(&fetchWorkers, "fetch-workers", defaultFetchWorkers, "Number of concurrent workers used to page through the users API.")
+		c.Flags().Float64Var(&rateLimit, "rate-limit", 0, "Maximum Mattermost API requests per second (0 = unlimited).")
+		c.Flags().BoolVar(&includeSSO, "include-sso", false, "Include LDAP/SAML-managed users, which are otherwise skipped (normally deactivated on the IdP side).")
+		c.Flags().BoolVar(&includeBots, "include-bots", false, "Include bot accounts, which are otherwise skipped.")
+		c.Flags().StringVar(&skipRoles, "skip-roles", "system_admin", "Comma-separated list of roles to exclude from consideration.")
+		c.Flags().StringVar(&requireRole, "require-role", "", "Limit consideration to users holding this role.")
+		c.Flags().StringVar(&skipUsers, "skip-users", "", "Comma-separated list of UserIDs to exclude.")
+		c.Flags().StringVar(&skipUsernamesFile, "skip-usernames-file", "", "Path to a file of usernames (one per line) to exclude.")
+	}
+
+	userDeactivateCmd.Flags().BoolVar(&userHardDelete, "hard-delete", false, "Hard delete users, rather than just marking them as inactive.")
+	userDeactivateCmd.Flags().BoolVar(&userAssumeYes, "yes", false, "Skip the interactive confirmation prompt and deactivate immediately.")
+	userDeactivateCmd.Flags().IntVar(&deleteWorkers, "delete-workers", defaultDeleteWorkers, "Number of concurrent workers used to deactivate/delete users.")
+	userDeactivateCmd.Flags().StringVar(&deactivateStateFile, "state-file", "", "Path to a notify state file; if set, only deactivate users whose grace period (see 'user notify') has elapsed.")
+	userDeactivateCmd.Flags().StringVar(&inputFile, "input-file", "", "Path to a CSV/username list (e.g. from 'user list --output csv'); if set, skips the Mattermost scan and deactivates exactly this list.")
+	userDeactivateCmd.Flags().StringVar(&exportFormat, "output", "csv", "Format used by the (E)xport prompt option: csv, json, or table.")
+	userDeactivateCmd.Flags().StringVar(&exportFile, "output-file", "", "Path used by the (E)xport prompt option; writes to stdout if unset.")
+
+	userNotifyCmd.Flags().StringVar(&notifyBefore, "notify-before", defaultNotifyBefore, "How long before deactivation to warn a user (e.g. 14d, 336h).")
+	userNotifyCmd.Flags().StringVar(&notifyStateFile, "state-file", defaultStateFile(), "Path to the notify state file used to track grace periods.")
+	userNotifyCmd.Flags().StringVar(&notifyTemplate, "notify-template", "", "Path to a Go text/template file for the notification message (fields: .Username, .DaysInactive, .DeactivationDate).")
+
+	userListCmd.Flags().StringVar(&outputFormat, "output", "", "Render identified users as csv, json, or table instead of the interactive list.")
+	userListCmd.Flags().StringVar(&outputFile, "output-file", "", "Write the --output report to this path instead of stdout.")
+
+	userCmd.AddCommand(userListCmd, userDeactivateCmd, userReactivateCmd, userNotifyCmd)
+	rootCmd.AddCommand(userCmd)
+}
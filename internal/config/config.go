@@ -0,0 +1,43 @@
+// Package config loads operator-supplied defaults for mm-inactive-users from a
+// YAML or TOML file, so that connection details and common flags don't have to
+// be repeated on every invocation or exported as environment variables.
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Defaults holds the values that may be pre-populated from a config file.
+// Command-line flags still take precedence over whatever is set here, and
+// values set here take precedence over environment variables.
+type Defaults struct {
+	URL    string `mapstructure:"url"`
+	Port   string `mapstructure:"port"`
+	Scheme string `mapstructure:"scheme"`
+	Token  string `mapstructure:"token"`
+	Team   string `mapstructure:"team"`
+	Age    int    `mapstructure:"age"`
+}
+
+// Load reads the config file at path (YAML or TOML, detected from its extension)
+// and returns the defaults found within it. An empty path is not an error - it
+// simply yields a zero-value Defaults, since supplying --config is optional.
+func Load(path string) (Defaults, error) {
+	var defaults Defaults
+	if path == "" {
+		return defaults, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return defaults, fmt.Errorf("unable to read config file %q: %w", path, err)
+	}
+	if err := v.Unmarshal(&defaults); err != nil {
+		return defaults, fmt.Errorf("unable to parse config file %q: %w", path, err)
+	}
+
+	return defaults, nil
+}
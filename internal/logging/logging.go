@@ -0,0 +1,52 @@
+// Package logging provides a very small leveled-logging helper used
+// throughout mm-inactive-users. It intentionally mirrors the original
+// flat LogMessage/DebugPrint helpers from the pre-Cobra CLI so that the
+// rest of the codebase didn't need to change its call sites when the
+// tool was split into packages.
+package logging
+
+import (
+	"log"
+	"os"
+)
+
+// LogLevel is used to refer to the type of message that will be written using the logging code.
+type LogLevel string
+
+const (
+	DebugLevel   LogLevel = "DEBUG"
+	InfoLevel    LogLevel = "INFO"
+	WarningLevel LogLevel = "WARNING"
+	ErrorLevel   LogLevel = "ERROR"
+)
+
+var debugMode bool
+
+// SetDebug enables or disables debug-level output for the lifetime of the process.
+func SetDebug(enabled bool) {
+	debugMode = enabled
+}
+
+// Debug reports whether debug-level output is currently enabled.
+func Debug() bool {
+	return debugMode
+}
+
+// LogMessage logs a formatted message to stdout or stderr, depending on level.
+func LogMessage(level LogLevel, message string) {
+	if level == ErrorLevel {
+		log.SetOutput(os.Stderr)
+	} else {
+		log.SetOutput(os.Stdout)
+	}
+	log.SetFlags(log.Ldate | log.Ltime)
+	log.Printf("[%s] %s\n", level, message)
+}
+
+// DebugPrint allows us to add debug messages into our code, which are only printed if we're running in debug mode.
+// Note that the command line flag '--debug' (or the MM_DEBUG environment variable) is used to enable this at runtime.
+func DebugPrint(message string) {
+	if debugMode {
+		LogMessage(DebugLevel, message)
+	}
+}
@@ -0,0 +1,20 @@
+// Package mattermost contains the HTTP client code used to talk to the
+// Mattermost REST API (user lookup, team lookup, deactivation, etc).
+// It has no knowledge of flags or subcommands - those live in cmd/ - so
+// it can be exercised independently by any of the CLI's subcommands.
+package mattermost
+
+import "fmt"
+
+// Connection holds the details needed to reach a Mattermost server.
+type Connection struct {
+	URL    string
+	Port   string
+	Scheme string
+	Token  string
+}
+
+// BaseURL returns the scheme://host:port prefix used to build API calls.
+func (c Connection) BaseURL() string {
+	return fmt.Sprintf("%s://%s:%s", c.Scheme, c.URL, c.Port)
+}
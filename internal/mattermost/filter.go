@@ -0,0 +1,100 @@
+package mattermost
+
+import "strings"
+
+// Filter controls which users are considered candidates for deactivation.
+// The zero value is not generally useful - NewFilter applies the same
+// defaults the original flat tool had (skip system_admin), with the
+// inactivity threshold supplied by the caller.
+type Filter struct {
+	MinInactiveDays int
+
+	// IncludeSSO includes LDAP/SAML-managed users, which are normally left
+	// for the identity provider to deactivate.
+	IncludeSSO bool
+
+	// IncludeBots includes bot accounts, which are normally preserved.
+	IncludeBots bool
+
+	// SkipRoles lists roles (e.g. "system_admin", "team_admin") that exclude
+	// a user from consideration.
+	SkipRoles map[string]bool
+
+	// RequireRole, if non-empty, limits consideration to users holding this role.
+	RequireRole string
+
+	// SkipUserIDs and SkipUsernames are explicit allow-lists.
+	SkipUserIDs   map[string]bool
+	SkipUsernames map[string]bool
+}
+
+// NewFilter returns a Filter requiring minInactiveDays of inactivity and
+// skipping system admins, matching the original tool's hardcoded behavior.
+func NewFilter(minInactiveDays int) Filter {
+	return Filter{
+		MinInactiveDays: minInactiveDays,
+		SkipRoles:       map[string]bool{"system_admin": true},
+		SkipUserIDs:     map[string]bool{},
+		SkipUsernames:   map[string]bool{},
+	}
+}
+
+// candidate holds the fields of a Mattermost user record needed to evaluate a Filter.
+type candidate struct {
+	id           string
+	username     string
+	roles        string
+	authService  string
+	isBot        bool
+	deleteAt     int64
+	lastActivity int64
+}
+
+// accepts reports whether a user should be considered for deactivation, and
+// the reason it was skipped if not (for debug logging).
+func (f Filter) accepts(c candidate) (bool, string) {
+	if c.deleteAt > 0 {
+		return false, "already disabled"
+	}
+
+	if !f.IncludeSSO && (c.authService == "ldap" || c.authService == "saml") {
+		return false, "managed by " + c.authService
+	}
+
+	if !f.IncludeBots && c.isBot {
+		return false, "bot account"
+	}
+
+	if f.SkipUserIDs[c.id] {
+		return false, "explicitly skipped by UserID"
+	}
+	if f.SkipUsernames[c.username] {
+		return false, "explicitly skipped by username"
+	}
+
+	roles := strings.Fields(c.roles)
+	for _, role := range roles {
+		if f.SkipRoles[role] {
+			return false, "has skipped role " + role
+		}
+	}
+
+	if f.RequireRole != "" {
+		hasRole := false
+		for _, role := range roles {
+			if role == f.RequireRole {
+				hasRole = true
+				break
+			}
+		}
+		if !hasRole {
+			return false, "does not hold required role " + f.RequireRole
+		}
+	}
+
+	if DaysAgo(c.lastActivity) < f.MinInactiveDays {
+		return false, "not inactive long enough"
+	}
+
+	return true, ""
+}
@@ -0,0 +1,37 @@
+package mattermost
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+)
+
+// ReactivateUser marks a previously deactivated user as active again.
+func ReactivateUser(conn Connection, userID string) error {
+	logging.DebugPrint("Reactivating user: " + userID)
+
+	apiURL := fmt.Sprintf("%s/api/v4/users/%s/active", conn.BaseURL(), userID)
+	body := bytes.NewBufferString(`{"active": true}`)
+
+	req, err := http.NewRequest("PUT", apiURL, body)
+	if err != nil {
+		return fmt.Errorf("error preparing PUT request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("PUT request failed for user %s: %w", userID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("REST call returned '%s' when attempting to reactivate user %s", resp.Status, userID)
+	}
+
+	logging.LogMessage(logging.InfoLevel, "Reactivated user: "+userID)
+	return nil
+}
@@ -0,0 +1,111 @@
+package mattermost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/buger/jsonparser"
+)
+
+// mattermostIDPattern matches a Mattermost-generated ID: 26 lowercase
+// alphanumeric characters. Usernames may also be 26 characters long, but
+// Mattermost usernames additionally allow uppercase-insensitive mixed case,
+// ".", "-", and "_", so matching the ID's narrower character set is enough
+// to tell them apart in an --input-file.
+var mattermostIDPattern = regexp.MustCompile(`^[a-z0-9]{26}$`)
+
+// GetUsersByIdentifiers resolves a mixed list of UserIDs and usernames (as
+// found in an --input-file) into full User records, via the bulk
+// /api/v4/users/ids and /api/v4/users/usernames endpoints.
+func GetUsersByIdentifiers(conn Connection, identifiers []string) (map[string]User, error) {
+	var ids, usernames []string
+	for _, identifier := range identifiers {
+		if mattermostIDPattern.MatchString(identifier) {
+			ids = append(ids, identifier)
+		} else {
+			usernames = append(usernames, identifier)
+		}
+	}
+
+	users := make(map[string]User)
+
+	if len(ids) > 0 {
+		found, err := fetchUsersBy(conn, "/api/v4/users/ids", ids)
+		if err != nil {
+			return nil, err
+		}
+		for id, user := range found {
+			users[id] = user
+		}
+	}
+
+	if len(usernames) > 0 {
+		found, err := fetchUsersBy(conn, "/api/v4/users/usernames", usernames)
+		if err != nil {
+			return nil, err
+		}
+		for id, user := range found {
+			users[id] = user
+		}
+	}
+
+	return users, nil
+}
+
+func fetchUsersBy(conn Connection, path string, values []string) (map[string]User, error) {
+	apiURL := conn.BaseURL() + path
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("REST call to %s returned '%s'", path, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]User)
+	_, err = jsonparser.ArrayEach(body, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		id, _ := jsonparser.GetString(value, "id")
+		username, _ := jsonparser.GetString(value, "username")
+		email, _ := jsonparser.GetString(value, "email")
+		firstname, _ := jsonparser.GetString(value, "first_name")
+		lastname, _ := jsonparser.GetString(value, "last_name")
+		lastActivity, _ := jsonparser.GetInt(value, "last_activity_at")
+
+		users[id] = User{
+			UserID:                id,
+			Username:              username,
+			Email:                 email,
+			FullName:              fmt.Sprintf("%s %s", firstname, lastname),
+			LastActivityOn:        EpochToDate(lastActivity),
+			DaysSinceLastActivity: DaysAgo(lastActivity),
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
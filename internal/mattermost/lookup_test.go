@@ -0,0 +1,24 @@
+package mattermost
+
+import "testing"
+
+func TestMattermostIDPattern(t *testing.T) {
+	tests := []struct {
+		identifier string
+		wantID     bool
+	}{
+		{"abcdefghijklmnopqrstuvwxy0", true},   // 26 lowercase alphanumeric chars
+		{"alice", false},                       // plain username
+		{"alice.smith", false},                 // username with allowed punctuation
+		{"Abcdefghijklmnopqrstuvwxy0", false},  // 26 chars but mixed case - a username
+		{"abcdefghijklmnopqrstuvwx_0", false},  // 26 chars but contains "_" - a username
+		{"abcdefghijklmnopqrstuvwxy", false},   // 25 chars - too short to be an ID
+		{"abcdefghijklmnopqrstuvwxy01", false}, // 27 chars - too long to be an ID
+	}
+
+	for _, tt := range tests {
+		if got := mattermostIDPattern.MatchString(tt.identifier); got != tt.wantID {
+			t.Errorf("mattermostIDPattern.MatchString(%q) = %v, want %v", tt.identifier, got, tt.wantID)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package mattermost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/buger/jsonparser"
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+)
+
+// GetMe returns the user ID associated with the connection's auth token.
+func GetMe(conn Connection) (string, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/users/me", conn.BaseURL())
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return jsonparser.GetString(body, "id")
+}
+
+// getDirectChannel creates (or fetches the existing) direct-message channel between
+// the connection's own user and the given user ID.
+func getDirectChannel(conn Connection, userID string) (string, error) {
+	me, err := GetMe(conn)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine calling user: %w", err)
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v4/channels/direct", conn.BaseURL())
+	payload, err := json.Marshal([]string{me, userID})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return "", fmt.Errorf("REST call returned '%s' when creating direct channel", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return jsonparser.GetString(body, "id")
+}
+
+// SendDirectMessage posts message into a direct-message channel with the given user.
+func SendDirectMessage(conn Connection, userID string, message string) error {
+	channelID, err := getDirectChannel(conn, userID)
+	if err != nil {
+		return err
+	}
+
+	apiURL := fmt.Sprintf("%s/api/v4/posts", conn.BaseURL())
+	payload, err := json.Marshal(map[string]string{
+		"channel_id": channelID,
+		"message":    message,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return fmt.Errorf("REST call returned '%s' when posting direct message", resp.Status)
+	}
+
+	logging.DebugPrint("Sent notification post to user: " + userID)
+	return nil
+}
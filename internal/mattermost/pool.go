@@ -0,0 +1,146 @@
+package mattermost
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+	"golang.org/x/time/rate"
+)
+
+// DeactivationReport summarizes the outcome of a concurrent deactivation run,
+// since silently logging-and-continuing per user gives operators no way to
+// know how many (if any) calls actually failed.
+type DeactivationReport struct {
+	Succeeded []string
+	Failed    map[string]error
+}
+
+// FetchUsersConcurrent walks every page of users using a pool of
+// fetchWorkers goroutines, each speculatively claiming the next page number
+// from a shared atomic counter. Workers stop once any one of them receives
+// an empty page (no more users) or an error. If limiter is non-nil, every
+// page request waits on it first, which also provides back-pressure - a
+// slow/rate-limited server throttles the workers rather than letting them
+// spin or pile up in-flight requests. An empty teamID fetches server-wide,
+// across every team.
+func FetchUsersConcurrent(conn Connection, teamID string, filter Filter, fetchWorkers int, limiter *rate.Limiter) (map[string]User, error) {
+	if fetchWorkers < 1 {
+		fetchWorkers = 1
+	}
+
+	usersMap := make(map[string]User)
+	var mu sync.Mutex
+
+	var nextPage int32
+	var stopped int32
+	var completed int32
+
+	errCh := make(chan error, fetchWorkers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < fetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stopped) == 0 {
+				page := int(atomic.AddInt32(&nextPage, 1) - 1)
+
+				if limiter != nil {
+					if err := limiter.Wait(context.Background()); err != nil {
+						errCh <- err
+						atomic.StoreInt32(&stopped, 1)
+						return
+					}
+				}
+
+				local := make(map[string]User)
+				more, err := GetUsersPage(conn, teamID, page, local, filter)
+				atomic.AddInt32(&completed, 1)
+
+				if err != nil {
+					errCh <- err
+					atomic.StoreInt32(&stopped, 1)
+					return
+				}
+
+				mu.Lock()
+				for id, user := range local {
+					usersMap[id] = user
+				}
+				mu.Unlock()
+
+				if !more {
+					atomic.StoreInt32(&stopped, 1)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	logging.DebugPrint("Fetched users using page fetch workers - pages completed: " + strconv.Itoa(int(completed)))
+
+	for err := range errCh {
+		if err != nil {
+			return usersMap, err
+		}
+	}
+
+	return usersMap, nil
+}
+
+// DeactivateUsersConcurrent drains users through a pool of deleteWorkers
+// goroutines, each issuing the deactivate/delete REST call independently.
+// If limiter is non-nil, every call waits on it first.
+func DeactivateUsersConcurrent(conn Connection, users map[string]User, hardDelete bool, deleteWorkers int, limiter *rate.Limiter) DeactivationReport {
+	if deleteWorkers < 1 {
+		deleteWorkers = 1
+	}
+
+	report := DeactivationReport{Failed: make(map[string]error)}
+	var mu sync.Mutex
+
+	jobs := make(chan User, deleteWorkers*2)
+	var wg sync.WaitGroup
+	var completed int32
+
+	for i := 0; i < deleteWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range jobs {
+				if limiter != nil {
+					_ = limiter.Wait(context.Background())
+				}
+
+				err := deactivateUser(conn, user, hardDelete)
+				atomic.AddInt32(&completed, 1)
+
+				mu.Lock()
+				if err != nil {
+					report.Failed[user.Username] = err
+				} else {
+					report.Succeeded = append(report.Succeeded, user.Username)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, user := range users {
+		jobs <- user
+	}
+	close(jobs)
+
+	wg.Wait()
+
+	logging.DebugPrint("Deactivation worker pool completed - users processed: " + strconv.Itoa(int(completed)))
+
+	return report
+}
+
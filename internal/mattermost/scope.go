@@ -0,0 +1,61 @@
+package mattermost
+
+import (
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// FetchUsersServerWide fetches candidates with a single, server-level users
+// listing (no in_team filter), which is far cheaper than iterating every
+// team individually. It returns ErrPermissionDenied if the supplied token
+// isn't allowed to list users server-wide, so callers can fall back to
+// FetchUsersAcrossTeams.
+func FetchUsersServerWide(conn Connection, filter Filter, fetchWorkers int, limiter *rate.Limiter) (map[string]User, error) {
+	return FetchUsersConcurrent(conn, "", filter, fetchWorkers, limiter)
+}
+
+// FetchUsersAcrossTeams fetches candidates from each of the given teams and
+// merges them by UserID via processUsers. The inactivity threshold in filter
+// is only applied after merging - a user active in one team must not be
+// flagged for deactivation just because they look inactive when viewed
+// through another team they also belong to.
+func FetchUsersAcrossTeams(conn Connection, teams []Team, filter Filter, fetchWorkers int, limiter *rate.Limiter) (map[string]User, error) {
+	perTeamFilter := filter
+	perTeamFilter.MinInactiveDays = 0
+
+	perTeam := make([]map[string]User, 0, len(teams))
+	for _, team := range teams {
+		users, err := FetchUsersConcurrent(conn, team.ID, perTeamFilter, fetchWorkers, limiter)
+		if err != nil {
+			return nil, fmt.Errorf("fetching users for team %q: %w", team.Name, err)
+		}
+		perTeam = append(perTeam, users)
+	}
+
+	candidates := make(map[string]User)
+	for id, user := range processUsers(perTeam) {
+		if user.DaysSinceLastActivity >= filter.MinInactiveDays {
+			candidates[id] = user
+		}
+	}
+
+	return candidates, nil
+}
+
+// processUsers merges the per-team candidate maps gathered by
+// FetchUsersAcrossTeams into a single set, keeping whichever record shows
+// the most recent activity (the smallest DaysSinceLastActivity) when the
+// same UserID appears in more than one team.
+func processUsers(perTeam []map[string]User) map[string]User {
+	merged := make(map[string]User)
+	for _, teamUsers := range perTeam {
+		for id, user := range teamUsers {
+			existing, ok := merged[id]
+			if !ok || user.DaysSinceLastActivity < existing.DaysSinceLastActivity {
+				merged[id] = user
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,34 @@
+package mattermost
+
+import "testing"
+
+func TestProcessUsersKeepsMostRecentActivity(t *testing.T) {
+	perTeam := []map[string]User{
+		{
+			"user-1": {UserID: "user-1", Username: "alice", DaysSinceLastActivity: 200},
+		},
+		{
+			"user-1": {UserID: "user-1", Username: "alice", DaysSinceLastActivity: 5},
+			"user-2": {UserID: "user-2", Username: "bob", DaysSinceLastActivity: 300},
+		},
+	}
+
+	merged := processUsers(perTeam)
+
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 merged users, got %d", len(merged))
+	}
+	if got := merged["user-1"].DaysSinceLastActivity; got != 5 {
+		t.Errorf("expected user-1's most recent activity (5 days) to win, got %d", got)
+	}
+	if got := merged["user-2"].DaysSinceLastActivity; got != 300 {
+		t.Errorf("expected user-2 unchanged at 300 days, got %d", got)
+	}
+}
+
+func TestProcessUsersEmptyInput(t *testing.T) {
+	merged := processUsers(nil)
+	if len(merged) != 0 {
+		t.Errorf("expected no users from empty input, got %d", len(merged))
+	}
+}
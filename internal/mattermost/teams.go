@@ -0,0 +1,148 @@
+package mattermost
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/buger/jsonparser"
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+)
+
+// GetTeamID translates a Mattermost team name into the internal Team ID, which is required for other API calls.
+func GetTeamID(conn Connection, teamName string) (string, error) {
+	logging.DebugPrint("Retrieving Team ID for team: " + teamName)
+
+	apiURL := fmt.Sprintf("%s/api/v4/teams/name/%s", conn.BaseURL(), url.QueryEscape(teamName))
+	logging.DebugPrint("Teams lookup URL: " + apiURL)
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Error preparing GET")
+		return "", err
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Failed to query Mattermost")
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		ListTeamsAndExit(conn)
+	}
+	if resp.StatusCode != 200 {
+		logging.LogMessage(logging.ErrorLevel, "Call to Get Teams failed!  Returned HTTP status: "+resp.Status)
+		os.Exit(4)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Unable to extract body data from Mattermost response")
+		return "", err
+	}
+
+	teamID, err := jsonparser.GetString(body, "id")
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Unable to retrieve team ID for team: "+teamName+" Error: "+err.Error())
+		return "", err
+	}
+
+	return teamID, nil
+}
+
+// Team represents the subset of a Mattermost team record that this tool cares about.
+type Team struct {
+	ID          string
+	Name        string
+	DisplayName string
+}
+
+// ListTeams returns every team visible to the supplied connection's token,
+// paging through /api/v4/teams until Mattermost returns an empty page.
+func ListTeams(conn Connection) ([]Team, error) {
+	logging.DebugPrint("Listing all teams")
+
+	var teams []Team
+	for page := 0; ; page++ {
+		more, err := getTeamsPage(conn, page, &teams)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+	}
+
+	return teams, nil
+}
+
+// getTeamsPage fetches a single page of /api/v4/teams, appending any teams
+// found to teams. It returns false once Mattermost stops returning further pages.
+func getTeamsPage(conn Connection, page int, teams *[]Team) (bool, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/teams?per_page=%d&page=%d", conn.BaseURL(), PageSize, page)
+	logging.DebugPrint("Teams lookup URL: " + apiURL)
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Error preparing GET")
+		return false, err
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Failed to query Mattermost")
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Unable to extract body data from Mattermost response to Get Teams")
+		return false, err
+	}
+
+	if string(body) == "[]" {
+		return false, nil
+	}
+
+	_, err = jsonparser.ArrayEach(body, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		id, _ := jsonparser.GetString(value, "id")
+		name, _ := jsonparser.GetString(value, "name")
+		displayName, _ := jsonparser.GetString(value, "display_name")
+		*teams = append(*teams, Team{ID: id, Name: name, DisplayName: displayName})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ListTeamsAndExit prints every team visible to the connection then exits the process.
+// It's intended to be called if the supplied team isn't found.
+func ListTeamsAndExit(conn Connection) {
+	logging.DebugPrint("In ListTeamsAndExit")
+
+	teams, err := ListTeams(conn)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Unable to retrieve team list: "+err.Error())
+		os.Exit(12)
+	}
+	if len(teams) == 0 {
+		logging.LogMessage(logging.ErrorLevel, "No Teams data returned from Mattermost!")
+		os.Exit(13)
+	}
+
+	fmt.Printf("\n\nTeams available in Mattermost (internal name in brackets):\n\n")
+	for _, team := range teams {
+		fmt.Printf(" - %s (%s)\n", team.DisplayName, team.Name)
+	}
+	fmt.Printf("\n\nPlease ensure that one of these teams is present in your command-line\n\n")
+	os.Exit(99)
+}
@@ -0,0 +1,80 @@
+package mattermost
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+	"golang.org/x/term"
+)
+
+const defaultTerminalHeight = 24
+
+// getTerminalHeight is a utility function used for pagination.
+func getTerminalHeight() int {
+	fd := int(os.Stdout.Fd())
+	if term.IsTerminal(fd) {
+		_, height, err := term.GetSize(fd)
+		if err == nil {
+			return height
+		}
+	}
+	return defaultTerminalHeight
+}
+
+// PromptForKeypress displays a message and waits for a keypress.
+// It takes 2 parameters:
+// prompt: a string to be displayed to alert the users what they need to do
+// allowedKeys: an array of strings for the keys that will be accepted.  Other keys will be ignored.
+func PromptForKeypress(prompt string, allowedKeys []string) (string, error) {
+	logging.DebugPrint("Waiting for keypress")
+
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Print(prompt)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		input = strings.TrimSpace(strings.ToUpper(input)) // Normalise the input
+
+		for _, key := range allowedKeys {
+			if input == strings.ToUpper(key) {
+				return input, nil // Return the valid keypress
+			}
+		}
+
+		fmt.Println("Invalid input.  Please try again.")
+	}
+}
+
+// PrintAllIdentifiedUsers writes the candidate user set to the terminal, paginating the
+// output to fit the current terminal height.
+func PrintAllIdentifiedUsers(users map[string]User) {
+	reader := bufio.NewReader(os.Stdin)
+	pageSize := getTerminalHeight() - 1 // We're subtracting 1 to allow for the prompt line
+	count := 2                          // Note that count starts at 2 to allow for the header lines
+
+	fmt.Printf("\nIdentified Users\n================\n\n")
+	for _, user := range users {
+		fmt.Printf("Username: %s, Email: %s, Full name: %s, Last Login: %s, Days Since Last Login: %d\n",
+			user.Username, user.Email, user.FullName,
+			user.LastActivityOn, user.DaysSinceLastActivity)
+
+		count++
+
+		if count%pageSize == 0 {
+			fmt.Printf("Enter 'Q' to quit, or 'enter' key to continue...")
+			input, _ := reader.ReadString('\n')
+			input = strings.ToUpper(input)
+			if input == "Q\n" || input == "Q\r\n" { // We're handling this for Linux/Mac and Windows alternatives
+				break
+			}
+		}
+	}
+	fmt.Printf("\nTotal users identified: %d\n\n", len(users))
+}
@@ -0,0 +1,172 @@
+package mattermost
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/jlandells/mm-inactive-users/internal/logging"
+)
+
+// ErrPermissionDenied is returned by GetUsersPage when Mattermost rejects a
+// users lookup as forbidden for the supplied token. Callers use this to fall
+// back from a server-wide listing to per-team iteration.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// PageSize is the number of users requested per page when paginating the Mattermost users API.
+const PageSize = 60
+
+// User represents the subset of a Mattermost user record that this tool cares about.
+type User struct {
+	UserID                string
+	Username              string
+	Email                 string
+	FullName              string
+	LastActivityOn        string
+	DaysSinceLastActivity int
+}
+
+// EpochToDate converts an Epoch time to a string representation of the date.
+func EpochToDate(epoch int64) string {
+	t := time.Unix(epoch/1000, 0) // Convert Epoch to *time.Time
+	return t.Format("02-01-2006") // Return date in DD-MM-YYYY format
+}
+
+// DaysAgo calculates how many days ago a date, represented by Epoch time, was.
+func DaysAgo(epoch int64) int {
+	now := time.Now()
+	then := time.Unix(epoch/1000, 0)
+	daysAgo := now.Sub(then).Hours() / 24 // Calculate difference in hours and convert to days
+	return int(daysAgo)
+}
+
+// GetUsersPage fetches a single page of users and merges any candidates for
+// deactivation (those accepted by filter) into usersMap. It returns false
+// once Mattermost stops returning further pages. If teamID is empty, the
+// lookup is server-wide (every user, regardless of team membership).
+func GetUsersPage(conn Connection, teamID string, page int, usersMap map[string]User, filter Filter) (bool, error) {
+	logging.DebugPrint("Getting users page: " + strconv.Itoa(page))
+
+	// sort=last_activity_at is only valid alongside in_team/in_channel - Mattermost
+	// rejects it with HTTP 400 on a server-wide lookup, so it's omitted below. That's
+	// harmless here since every page is scanned regardless of order.
+	apiURL := fmt.Sprintf("%s/api/v4/users?per_page=%d&page=%d",
+		conn.BaseURL(), PageSize, page)
+	if teamID != "" {
+		apiURL = fmt.Sprintf("%s/api/v4/users?in_team=%s&sort=last_activity_at&per_page=%d&page=%d",
+			conn.BaseURL(), teamID, PageSize, page)
+	}
+	logging.DebugPrint("Users lookup URL: " + apiURL)
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Error preparing GET")
+		return false, err
+	}
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Failed to query Mattermost")
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return false, ErrPermissionDenied
+	}
+	if resp.StatusCode != http.StatusOK {
+		logging.LogMessage(logging.ErrorLevel, "Call to Get Users failed!  Returned HTTP status: "+resp.Status)
+		return false, fmt.Errorf("users lookup returned status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logging.LogMessage(logging.ErrorLevel, "Unable to extract body data from Mattermost response")
+		return false, err
+	}
+
+	// Check if the response body is empty (indicating no more items)
+	if string(body) == "[]" {
+		return false, nil // No more items
+	}
+
+	_, err = jsonparser.ArrayEach(body, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		id, _ := jsonparser.GetString(value, "id")
+		username, _ := jsonparser.GetString(value, "username")
+		email, _ := jsonparser.GetString(value, "email")
+		firstname, _ := jsonparser.GetString(value, "first_name")
+		lastname, _ := jsonparser.GetString(value, "last_name")
+		lastActivity, _ := jsonparser.GetInt(value, "last_activity_at")
+		deleteAt, _ := jsonparser.GetInt(value, "delete_at")
+		roles, _ := jsonparser.GetString(value, "roles")
+		authService, _ := jsonparser.GetString(value, "auth_service")
+		isBot, _ := jsonparser.GetBoolean(value, "is_bot")
+
+		c := candidate{
+			id:           id,
+			username:     username,
+			roles:        roles,
+			authService:  authService,
+			isBot:        isBot,
+			deleteAt:     deleteAt,
+			lastActivity: lastActivity,
+		}
+
+		ok, reason := filter.accepts(c)
+		if !ok {
+			logging.DebugPrint("Skipping user " + username + " - " + reason)
+			return
+		}
+
+		logging.DebugPrint("Found user: " + username + " for deactivation")
+		userFullname := fmt.Sprintf("%s %s", firstname, lastname)
+		usersMap[id] = User{
+			UserID:                id,
+			Username:              username,
+			Email:                 email,
+			FullName:              userFullname,
+			LastActivityOn:        EpochToDate(lastActivity),
+			DaysSinceLastActivity: DaysAgo(lastActivity)}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// deactivateUser issues the DELETE request for a single user, either marking
+// them inactive or (if hardDelete is set) permanently removing them.
+func deactivateUser(conn Connection, user User, hardDelete bool) error {
+	var apiURL string
+	if hardDelete {
+		apiURL = fmt.Sprintf("%s/api/v4/users/%s?permanent=true", conn.BaseURL(), user.UserID)
+	} else {
+		apiURL = fmt.Sprintf("%s/api/v4/users/%s", conn.BaseURL(), user.UserID)
+	}
+
+	req, err := http.NewRequest("DELETE", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("error preparing API call for user %s: %w", user.Username, err)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+conn.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("DELETE request failed for user %s: %w", user.Username, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("REST call returned '%s' when attempting to deactivate/delete user %s", resp.Status, user.Username)
+	}
+
+	return nil
+}
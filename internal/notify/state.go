@@ -0,0 +1,81 @@
+// Package notify implements the pre-deactivation notification and grace-period
+// workflow: it renders a warning message for a candidate user, sends it as a
+// Mattermost direct message, and persists a small state file so that a later
+// run of `user deactivate` only acts once the grace period has elapsed.
+package notify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jlandells/mm-inactive-users/internal/mattermost"
+)
+
+// Entry records when a user was warned and when their grace period ends.
+type Entry struct {
+	UserID                  string    `json:"user_id"`
+	NotifiedAt              time.Time `json:"notified_at"`
+	ScheduledDeactivationAt time.Time `json:"scheduled_deactivation_at"`
+}
+
+// State is keyed by Mattermost user ID.
+type State map[string]Entry
+
+// Load reads the state file at path. A missing file is not an error - it
+// simply means no user has been notified yet.
+func Load(path string) (State, error) {
+	state := make(State)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return state, nil
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Save writes the state file at path, creating its parent directory if needed.
+func Save(path string, state State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// ReadyForDeactivation returns the subset of candidates that have both been
+// notified and whose grace period has elapsed. Users with no state entry
+// (never notified) are excluded, since deactivating them without warning is
+// exactly what this workflow is meant to prevent.
+func ReadyForDeactivation(candidates map[string]mattermost.User, state State, now time.Time) map[string]mattermost.User {
+	ready := make(map[string]mattermost.User)
+	for id, candidate := range candidates {
+		entry, notified := state[id]
+		if !notified {
+			continue
+		}
+		if now.Before(entry.ScheduledDeactivationAt) {
+			continue
+		}
+		ready[id] = candidate
+	}
+	return ready
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultTemplate is used whenever --notify-template isn't supplied.
+const defaultTemplate = `Hi {{.Username}}, our records show you haven't logged into Mattermost for ` +
+	`{{.DaysInactive}} days. If you don't log in before {{.DeactivationDate}}, your account will be deactivated.`
+
+// MessageData is the set of fields made available to a notification template.
+type MessageData struct {
+	Username         string
+	DaysInactive     int
+	DeactivationDate string
+}
+
+// RenderMessage renders the notification message for a candidate user. If
+// templatePath is empty, a sensible built-in default is used.
+func RenderMessage(templatePath string, data MessageData) (string, error) {
+	body := defaultTemplate
+	if templatePath != "" {
+		raw, err := os.ReadFile(templatePath)
+		if err != nil {
+			return "", fmt.Errorf("unable to read notify template %q: %w", templatePath, err)
+		}
+		body = string(raw)
+	}
+
+	tmpl, err := template.New("notify").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse notify template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("unable to render notify template: %w", err)
+	}
+
+	return out.String(), nil
+}
+
+// ParseGracePeriod parses a grace-period duration such as "14d", in addition
+// to everything time.ParseDuration already accepts ("336h", "2h30m", etc),
+// since Mattermost operators think in days, not hours.
+func ParseGracePeriod(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid grace period %q: %w", value, err)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
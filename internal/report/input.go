@@ -0,0 +1,42 @@
+package report
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// ReadIdentifiers reads a --input-file: either a CSV previously produced by
+// this tool (user_id is the first column) or a plain list of one UserID or
+// username per line. It returns the raw identifiers found, letting the
+// caller decide which are UserIDs and which need resolving as usernames.
+func ReadIdentifiers(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var identifiers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		field := line
+		if idx := strings.Index(line, ","); idx != -1 {
+			field = line[:idx]
+		}
+		field = strings.TrimSpace(field)
+
+		if field == "user_id" || field == "username" || field == "UserID" || field == "Username" {
+			continue // header row
+		}
+
+		identifiers = append(identifiers, field)
+	}
+
+	return identifiers, scanner.Err()
+}
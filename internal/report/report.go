@@ -0,0 +1,105 @@
+// Package report renders the set of identified candidate users as CSV, JSON,
+// or a plain-text table, for machine consumption (compliance export) or
+// re-import by a later run (see the --input-file flag on `user deactivate`).
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jlandells/mm-inactive-users/internal/mattermost"
+)
+
+// Format identifies the output format for a report.
+type Format string
+
+const (
+	CSV   Format = "csv"
+	JSON  Format = "json"
+	Table Format = "table"
+)
+
+// ParseFormat validates a --output flag value.
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case CSV, JSON, Table:
+		return Format(value), nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q (expected csv, json, or table)", value)
+	}
+}
+
+var csvHeader = []string{"user_id", "username", "email", "full_name", "last_activity_on", "days_since_last_activity"}
+
+// Write renders users to w in the requested format.
+func Write(w io.Writer, format Format, users map[string]mattermost.User) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, users)
+	case JSON:
+		return writeJSON(w, users)
+	case Table:
+		return writeTable(w, users)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// WriteToFile renders users to the file at path in the requested format.
+func WriteToFile(path string, format Format, users map[string]mattermost.User) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create output file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	return Write(f, format, users)
+}
+
+func writeCSV(w io.Writer, users map[string]mattermost.User) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, user := range users {
+		record := []string{
+			user.UserID,
+			user.Username,
+			user.Email,
+			user.FullName,
+			user.LastActivityOn,
+			strconv.Itoa(user.DaysSinceLastActivity),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJSON(w io.Writer, users map[string]mattermost.User) error {
+	list := make([]mattermost.User, 0, len(users))
+	for _, user := range users {
+		list = append(list, user)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+func writeTable(w io.Writer, users map[string]mattermost.User) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USERNAME\tEMAIL\tFULL NAME\tLAST LOGIN\tDAYS INACTIVE")
+	for _, user := range users {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n",
+			user.Username, user.Email, user.FullName, user.LastActivityOn, user.DaysSinceLastActivity)
+	}
+	return tw.Flush()
+}